@@ -4,15 +4,23 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"math/rand"
 	"net"
 	"sync"
 	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
 )
 
 var errInconsistent = errors.New("[error] Inconsistent record count from CF_NS1 and CF_NS2")
 
-func resolver(address string) net.Resolver {
-	return net.Resolver{
+// propagationBackoffCap is the largest interval Propagation will wait
+// between polls, regardless of how many attempts have elapsed.
+const propagationBackoffCap = 30 * time.Second
+
+func resolver(address string) *net.Resolver {
+	return &net.Resolver{
 		PreferGo: true,
 		Dial: func(ctx context.Context, network, _ string) (conn net.Conn, err error) {
 			d := net.Dialer{}
@@ -33,7 +41,7 @@ func resolver(address string) net.Resolver {
 	}
 }
 
-func lookupCompareTXT(rs1, rs2 net.Resolver, name string) ([]string, error) {
+func lookupCompareTXT(rs1, rs2 *net.Resolver, name string) ([]string, error) {
 	wg := &sync.WaitGroup{}
 
 	var res1, res2 []string
@@ -92,3 +100,208 @@ func strSliceLookup(haystack []string, needle string) bool {
 	}
 	return false
 }
+
+// querySOA sends a raw SOA query for zone directly to the nameserver rs is
+// configured to dial, bypassing the stdlib resolver's lookup methods (which
+// have no SOA lookup) and using only its Dial function. The response code is
+// returned alongside the result, and also with the error when a reply
+// arrived but held no SOA record, so callers can include it in
+// propagation-check log events; on earlier errors it is 0.
+func querySOA(ctx context.Context, rs *net.Resolver, zone string) (*dnsmessage.SOAResource, dnsmessage.RCode, error) {
+	conn, err := rs.Dial(ctx, "udp", "")
+	if err != nil {
+		return nil, 0, err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	name, err := dnsmessage.NewName(zone + ".")
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid zone name %q: %w", zone, err)
+	}
+
+	query := dnsmessage.Message{
+		Header: dnsmessage.Header{ID: uint16(rand.Intn(1 << 16))},
+		Questions: []dnsmessage.Question{{
+			Name:  name,
+			Type:  dnsmessage.TypeSOA,
+			Class: dnsmessage.ClassINET,
+		}},
+	}
+	packed, err := query.Pack()
+	if err != nil {
+		return nil, 0, err
+	}
+	if _, err := conn.Write(packed); err != nil {
+		return nil, 0, err
+	}
+
+	buf := make([]byte, 512)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, 0, err
+	}
+	var reply dnsmessage.Message
+	if err := reply.Unpack(buf[:n]); err != nil {
+		return nil, 0, err
+	}
+	for _, a := range reply.Answers {
+		if soa, ok := a.Body.(*dnsmessage.SOAResource); ok {
+			return soa, reply.Header.RCode, nil
+		}
+	}
+	return nil, reply.Header.RCode, fmt.Errorf("no SOA record found for zone %q", zone)
+}
+
+// jitter returns d scaled by a random factor in [0.8, 1.2], so that
+// concurrently-polling clients don't all retry in lockstep.
+func jitter(d time.Duration) time.Duration {
+	return time.Duration(float64(d) * (0.8 + 0.4*rand.Float64()))
+}
+
+// PropagationConfig controls how long Propagation waits for a TXT record
+// to become consistent across a zone's authoritative nameservers, and how
+// aggressively it polls while waiting.
+type PropagationConfig struct {
+	// Timeout bounds the overall wait; Propagation gives up and returns an
+	// error once it elapses.
+	Timeout time.Duration
+	// Interval seeds the first poll backoff, which then grows
+	// exponentially up to propagationBackoffCap.
+	Interval time.Duration
+}
+
+// Check is one name=value TXT record Propagation must observe, consistently,
+// on both nameservers before it considers propagation complete. A
+// certificate covering multiple SAN domains that share a zone produces one
+// Check per domain, so they can all be waited on together.
+type Check struct {
+	Name  string
+	Value string
+}
+
+// allConsistent reports whether every check's value is visible and
+// consistent across rs1 and rs2. A check failing with errInconsistent (the
+// two nameservers disagree, which is expected mid-propagation) is logged at
+// debug level rather than surfaced to the caller.
+func allConsistent(logger *slog.Logger, rs1, rs2 *net.Resolver, checks []Check) bool {
+	for _, c := range checks {
+		dnsRes, err := lookupCompareTXT(rs1, rs2, c.Name)
+		if err != nil {
+			if errors.Is(err, errInconsistent) {
+				logger.Debug("nameservers disagree on TXT record, will retry", "event", "propagation_inconsistent", "domain", c.Name)
+			}
+			return false
+		}
+		if !strSliceLookup(dnsRes, c.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// Propagation calls create to add the challenge record(s), then waits until
+// every check in checks is visible and consistent on both rs1 and rs2. A
+// matching TXT answer alone is not sufficient: per RFC 2308 a nameserver can
+// keep serving a cached negative answer after the record exists underneath
+// it, so Propagation also gates on the zone's SOA serial observed on each
+// nameserver reaching at least the serial seen immediately after create
+// returns. If every check is already visible and consistent before create
+// is called, create is skipped entirely. ns1/ns2 and zoneID are only used
+// to annotate log events; zoneName is the actual zone apex queried for SOA.
+func Propagation(logger *slog.Logger, rs1, rs2 *net.Resolver, ns1, ns2, zoneID, zoneName string, checks []Check, cfg PropagationConfig, create func() error) error {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+	defer cancel()
+
+	if allConsistent(logger, rs1, rs2, checks) {
+		logger.Debug("challenge record(s) already propagated", "event", "propagation_skip", "domain", zoneName, "zone_id", zoneID)
+		return nil
+	}
+
+	if err := create(); err != nil {
+		return err
+	}
+
+	soa1, rcode1, err := querySOA(ctx, rs1, zoneName)
+	if err != nil {
+		return fmt.Errorf("failed to query SOA from first nameserver (rcode=%v): %w", rcode1, err)
+	}
+	soa2, rcode2, err := querySOA(ctx, rs2, zoneName)
+	if err != nil {
+		return fmt.Errorf("failed to query SOA from second nameserver (rcode=%v): %w", rcode2, err)
+	}
+	targetSerial := soa1.Serial
+	if soa2.Serial > targetSerial {
+		targetSerial = soa2.Serial
+	}
+
+	interval := cfg.Interval
+
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("gave up waiting for challenge record(s) to propagate after %d attempt(s): %w", attempt-1, ctx.Err())
+		case <-time.After(interval):
+		}
+
+		soa1, rcode1, soaErr1 := querySOA(ctx, rs1, zoneName)
+		soa2, _, soaErr2 := querySOA(ctx, rs2, zoneName)
+		consistent := soaErr1 == nil && soaErr2 == nil &&
+			soa1.Serial >= targetSerial && soa2.Serial >= targetSerial &&
+			allConsistent(logger, rs1, rs2, checks)
+
+		logger.Debug("checked challenge record propagation",
+			"event", "propagation_check",
+			"domain", zoneName,
+			"zone_id", zoneID,
+			"attempt", attempt,
+			"ns1", ns1,
+			"ns2", ns2,
+			"rcode", rcode1,
+			"elapsed_ms", time.Since(start).Milliseconds(),
+		)
+
+		if consistent {
+			logger.Info("challenge record(s) propagated", "event", "propagation_complete", "domain", zoneName, "zone_id", zoneID, "attempt", attempt, "elapsed_ms", time.Since(start).Milliseconds())
+			return nil
+		}
+
+		interval = jitter(interval * 2)
+		if interval > propagationBackoffCap {
+			interval = propagationBackoffCap
+		}
+	}
+}
+
+// parallelize calls fn(i) for i in [0,n) using up to maxParallel concurrent
+// goroutines, and returns the first non-nil error once all calls complete.
+func parallelize(n, maxParallel int, fn func(i int) error) error {
+	if maxParallel < 1 {
+		maxParallel = 1
+	}
+	sem := make(chan struct{}, maxParallel)
+	wg := &sync.WaitGroup{}
+	errs := make([]error, n)
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			errs[i] = fn(i)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,351 @@
+package cloudflare
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/alexrsagen/go-certbot-cloudflare/internal/provider"
+)
+
+const apiBase = "https://api.cloudflare.com/client/v4/"
+
+// forbiddenFilterCode is the Cloudflare API error code returned when the
+// credentials in use (typically a scoped API Token) are not permitted to
+// filter the zones list by name.
+const forbiddenFilterCode = 9109
+
+// invalidZoneCode is the Cloudflare API error code returned when the zone ID
+// in the request no longer identifies a zone, e.g. because it was deleted.
+const invalidZoneCode = 1001
+
+// errsHaveCode reports whether code appears among errs.
+func errsHaveCode(errs []responseError, code int) bool {
+	for i := range errs {
+		if errs[i].Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+type createDNSRecord struct {
+	Type     string `json:"type"`
+	Name     string `json:"name"`
+	Content  string `json:"content"`
+	TTL      uint32 `json:"ttl,omitempty"`
+	Priority uint16 `json:"priority,omitempty"`
+	Proxied  bool   `json:"proxied,omitempty"`
+}
+
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type responseOwner struct {
+	ID        string `json:"id"`
+	Email     string `json:"email"`
+	OwnerType string `json:"owner_type"`
+}
+
+type responsePlan struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Price        int    `json:"price"`
+	Currency     string `json:"currency"`
+	Frequency    string `json:"frequency"`
+	LegacyID     string `json:"legacy_id"`
+	IsSubscribed bool   `json:"is_subscribed"`
+	CanSubscribe bool   `json:"can_subscribe"`
+}
+
+type responseZone struct {
+	ID                  string        `json:"id"`
+	Name                string        `json:"name"`
+	DevelopmentMode     int           `json:"development_mode"`
+	OriginalNameservers []string      `json:"original_name_servers"`
+	OriginalRegistrar   string        `json:"original_registrar"`
+	OriginalDNSHost     string        `json:"original_dns_host"`
+	CreatedOn           string        `json:"created_on"`
+	ModifiedOn          string        `json:"modified_on"`
+	Owner               responseOwner `json:"owner"`
+	Permissions         []string      `json:"permissions"`
+	Plan                responsePlan  `json:"plan"`
+	PlanPending         responsePlan  `json:"plan_pending"`
+	Status              string        `json:"status"`
+	Paused              bool          `json:"paused"`
+	Type                string        `json:"type"`
+	Nameservers         []string      `json:"name_servers"`
+}
+
+type responseRecordID struct {
+	ID string `json:"id"`
+}
+
+type responseRecord struct {
+	responseRecordID
+	Type       string `json:"type"`
+	Name       string `json:"name"`
+	Content    string `json:"content"`
+	Proxiable  bool   `json:"proxiable"`
+	Proxied    bool   `json:"proxied"`
+	TTL        int    `json:"ttl"`
+	Locked     bool   `json:"locked"`
+	ZoneID     string `json:"zone_id"`
+	ZoneName   string `json:"zone_name"`
+	CreatedOn  string `json:"created_on"`
+	ModifiedOn string `json:"modified_on"`
+}
+
+type responseResultInfo struct {
+	Page       int `json:"page"`
+	PerPage    int `json:"per_page"`
+	Count      int `json:"count"`
+	TotalCount int `json:"total_count"`
+}
+
+type listZonesResponse struct {
+	Success    bool               `json:"success"`
+	Errors     []responseError    `json:"errors"`
+	Result     []responseZone     `json:"result"`
+	ResultInfo responseResultInfo `json:"result_info"`
+}
+
+type listRecordsResponse struct {
+	Success    bool               `json:"success"`
+	Errors     []responseError    `json:"errors"`
+	Result     []responseRecord   `json:"result"`
+	ResultInfo responseResultInfo `json:"result_info"`
+}
+
+type deleteRecordResponse struct {
+	Success bool             `json:"success"`
+	Errors  []responseError  `json:"errors"`
+	Result  responseRecordID `json:"result"`
+}
+
+type createRecordResponse struct {
+	Success bool            `json:"success"`
+	Errors  []responseError `json:"errors"`
+	Result  responseRecord  `json:"result"`
+}
+
+// client is a minimal Cloudflare API v4 HTTP client, authenticating with
+// either a scoped API Token (preferred) or a legacy email + global key
+// pair.
+type client struct {
+	apiAccessToken string
+	apiEmail       string
+	apiKey         string
+}
+
+func (c *client) setAuth(req *http.Request) {
+	if c.apiAccessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiAccessToken)
+	} else {
+		req.Header.Set("X-Auth-Email", c.apiEmail)
+		req.Header.Set("X-Auth-Key", c.apiKey)
+	}
+}
+
+func (c *client) get(urlExt string, v url.Values) (*http.Response, error) {
+	base, err := url.Parse(apiBase)
+	if err != nil {
+		return nil, err
+	}
+	ext, err := url.Parse(urlExt)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("GET", base.ResolveReference(ext).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if v != nil {
+		req.URL.RawQuery = v.Encode()
+	}
+	req.Header.Set("Content-Type", "application/json")
+	c.setAuth(req)
+	return http.DefaultClient.Do(req)
+}
+
+func (c *client) delete(urlExt string, v url.Values) (*http.Response, error) {
+	base, err := url.Parse(apiBase)
+	if err != nil {
+		return nil, err
+	}
+	ext, err := url.Parse(urlExt)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("DELETE", base.ResolveReference(ext).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if v != nil {
+		req.URL.RawQuery = v.Encode()
+	}
+	req.Header.Set("Content-Type", "application/json")
+	c.setAuth(req)
+	return http.DefaultClient.Do(req)
+}
+
+func (c *client) postJSON(urlExt string, v interface{}) (*http.Response, error) {
+	base, err := url.Parse(apiBase)
+	if err != nil {
+		return nil, err
+	}
+	ext, err := url.Parse(urlExt)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", base.ResolveReference(ext).String(), bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	c.setAuth(req)
+	return http.DefaultClient.Do(req)
+}
+
+// findZone looks up the Cloudflare zone named zoneDomain, returning nil if
+// no such zone exists in the account. It first tries a server-side name
+// filter, which is the cheapest lookup, and falls back to paging through
+// the full zone list when the credentials in use are not scoped to allow
+// filtering (as is the case for some API Tokens).
+func (c *client) findZone(zoneDomain string) (*responseZone, error) {
+	httpRes, err := c.get("zones", url.Values{
+		"name":     []string{zoneDomain},
+		"status":   []string{"active"},
+		"page":     []string{"1"},
+		"per_page": []string{"1"},
+		"match":    []string{"all"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	zonesRes := &listZonesResponse{}
+	d := json.NewDecoder(httpRes.Body)
+	if err = d.Decode(zonesRes); err != nil {
+		return nil, err
+	}
+	if !zonesRes.Success {
+		for i := range zonesRes.Errors {
+			if zonesRes.Errors[i].Code == forbiddenFilterCode {
+				return c.findZoneByListing(zoneDomain)
+			}
+		}
+		return nil, fmt.Errorf("failed to look up zone: %v", zonesRes.Errors)
+	}
+	if len(zonesRes.Result) == 0 {
+		return nil, nil
+	}
+	return &zonesRes.Result[0], nil
+}
+
+// findZoneByListing pages through every zone visible to the credentials in
+// use and matches zoneDomain client-side. Used as a fallback when the
+// `name=` server-side filter is forbidden by an API Token's scope.
+func (c *client) findZoneByListing(zoneDomain string) (*responseZone, error) {
+	for page := 1; ; page++ {
+		httpRes, err := c.get("zones", url.Values{
+			"status":   []string{"active"},
+			"page":     []string{strconv.Itoa(page)},
+			"per_page": []string{"50"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		zonesRes := &listZonesResponse{}
+		d := json.NewDecoder(httpRes.Body)
+		if err = d.Decode(zonesRes); err != nil {
+			return nil, err
+		}
+		if !zonesRes.Success {
+			return nil, fmt.Errorf("failed to look up zone: %v", zonesRes.Errors)
+		}
+		for i := range zonesRes.Result {
+			if zonesRes.Result[i].Name == zoneDomain {
+				return &zonesRes.Result[i], nil
+			}
+		}
+		if len(zonesRes.Result) == 0 || page*zonesRes.ResultInfo.PerPage >= zonesRes.ResultInfo.TotalCount {
+			return nil, nil
+		}
+	}
+}
+
+func (c *client) listTXT(zoneID, name string) ([]responseRecord, error) {
+	httpRes, err := c.get("zones/"+zoneID+"/dns_records", url.Values{
+		"type":     []string{"TXT"},
+		"name":     []string{name},
+		"page":     []string{"1"},
+		"per_page": []string{"100"},
+		"match":    []string{"all"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	recordsRes := &listRecordsResponse{}
+	d := json.NewDecoder(httpRes.Body)
+	if err = d.Decode(recordsRes); err != nil {
+		return nil, err
+	}
+	if !recordsRes.Success {
+		if errsHaveCode(recordsRes.Errors, invalidZoneCode) {
+			return nil, fmt.Errorf("failed to list TXT records: %v: %w", recordsRes.Errors, provider.ErrZoneInvalid)
+		}
+		return nil, fmt.Errorf("failed to list TXT records: %v", recordsRes.Errors)
+	}
+	return recordsRes.Result, nil
+}
+
+func (c *client) createTXT(zoneID, name, value string) error {
+	httpRes, err := c.postJSON("zones/"+zoneID+"/dns_records", &createDNSRecord{
+		Type:    "TXT",
+		Name:    name,
+		Content: value,
+	})
+	if err != nil {
+		return err
+	}
+	createRes := &createRecordResponse{}
+	d := json.NewDecoder(httpRes.Body)
+	if err = d.Decode(createRes); err != nil {
+		return err
+	}
+	if !createRes.Success {
+		if errsHaveCode(createRes.Errors, invalidZoneCode) {
+			return fmt.Errorf("failed to create challenge record: %v: %w", createRes.Errors, provider.ErrZoneInvalid)
+		}
+		return fmt.Errorf("failed to create challenge record: %v", createRes.Errors)
+	}
+	return nil
+}
+
+func (c *client) deleteRecord(zoneID, recordID string) error {
+	httpRes, err := c.delete("zones/"+zoneID+"/dns_records/"+recordID, nil)
+	if err != nil {
+		return err
+	}
+	deleteRes := &deleteRecordResponse{}
+	d := json.NewDecoder(httpRes.Body)
+	if err = d.Decode(deleteRes); err != nil {
+		return err
+	}
+	if !deleteRes.Success {
+		if errsHaveCode(deleteRes.Errors, invalidZoneCode) {
+			return fmt.Errorf("failed to delete challenge record: %v: %w", deleteRes.Errors, provider.ErrZoneInvalid)
+		}
+		return fmt.Errorf("failed to delete challenge record: %v", deleteRes.Errors)
+	}
+	return nil
+}
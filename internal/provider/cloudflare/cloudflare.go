@@ -0,0 +1,144 @@
+// Package cloudflare implements provider.Provider for Cloudflare DNS,
+// authenticating with either a scoped API Token or a legacy email + global
+// key pair.
+package cloudflare
+
+import (
+	"os"
+	"sync"
+
+	"github.com/go-ini/ini"
+
+	"github.com/alexrsagen/go-certbot-cloudflare/internal/provider"
+)
+
+const name = "cloudflare"
+
+func init() {
+	provider.Register(name, New)
+}
+
+// Cloudflare implements provider.Provider against the Cloudflare API v4.
+type Cloudflare struct {
+	client *client
+
+	mu          sync.Mutex
+	nameservers map[string][]string // zone ID -> authoritative nameservers
+}
+
+// New constructs a Cloudflare provider, preferring a CF_DNS_API_TOKEN over
+// CF_API_EMAIL/CF_API_KEY, and falling back to the renewal ini file's
+// [go-certbot-dns-cloudflare] section for whichever of those are not set in
+// the environment.
+func New(renewFile *ini.File) (provider.Provider, error) {
+	apiAccessToken := os.Getenv("CF_DNS_API_TOKEN")
+	apiEmail := os.Getenv("CF_API_EMAIL")
+	apiKey := os.Getenv("CF_API_KEY")
+
+	if apiAccessToken == "" && (apiEmail == "" || apiKey == "") && renewFile != nil {
+		section := renewFile.Section(provider.SectionName(name))
+		if key := section.Key("cf_dns_api_token"); key != nil && key.String() != "" {
+			apiAccessToken = key.String()
+		} else {
+			if apiEmail == "" {
+				if key := section.Key("cf_api_email"); key != nil {
+					apiEmail = key.String()
+				}
+			}
+			if apiKey == "" {
+				if key := section.Key("cf_api_key"); key != nil {
+					apiKey = key.String()
+				}
+			}
+		}
+	}
+
+	return &Cloudflare{
+		client: &client{
+			apiAccessToken: apiAccessToken,
+			apiEmail:       apiEmail,
+			apiKey:         apiKey,
+		},
+		nameservers: make(map[string][]string),
+	}, nil
+}
+
+// FindZone implements provider.Provider.
+func (p *Cloudflare) FindZone(domain string) (*provider.Zone, error) {
+	z, err := p.client.findZone(domain)
+	if err != nil {
+		return nil, err
+	}
+	if z == nil {
+		return nil, nil
+	}
+	p.mu.Lock()
+	p.nameservers[z.ID] = z.Nameservers
+	p.mu.Unlock()
+	return &provider.Zone{ID: z.ID, Name: z.Name}, nil
+}
+
+// UpsertTXT implements provider.Provider.
+func (p *Cloudflare) UpsertTXT(zone *provider.Zone, name, value string) error {
+	records, err := p.client.listTXT(zone.ID, name)
+	if err != nil {
+		return err
+	}
+	for i := range records {
+		if records[i].Content == value {
+			return nil
+		}
+	}
+	return p.client.createTXT(zone.ID, name, value)
+}
+
+// DeleteTXT implements provider.Provider.
+func (p *Cloudflare) DeleteTXT(zone *provider.Zone, name, value string) error {
+	records, err := p.client.listTXT(zone.ID, name)
+	if err != nil {
+		return err
+	}
+	for i := range records {
+		if records[i].Content != value {
+			continue
+		}
+		if err := p.client.deleteRecord(zone.ID, records[i].ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AuthoritativeNameservers implements provider.Provider.
+func (p *Cloudflare) AuthoritativeNameservers(zone *provider.Zone) ([]string, error) {
+	p.mu.Lock()
+	ns, ok := p.nameservers[zone.ID]
+	p.mu.Unlock()
+	if ok {
+		return ns, nil
+	}
+	z, err := p.client.findZone(zone.Name)
+	if err != nil {
+		return nil, err
+	}
+	if z == nil {
+		return nil, nil
+	}
+	p.mu.Lock()
+	p.nameservers[z.ID] = z.Nameservers
+	p.mu.Unlock()
+	return z.Nameservers, nil
+}
+
+// SaveCredentials implements provider.CredentialSaver.
+func (p *Cloudflare) SaveCredentials(section *ini.Section) error {
+	if p.client.apiAccessToken != "" {
+		_, err := section.NewKey("cf_dns_api_token", p.client.apiAccessToken)
+		return err
+	}
+	if _, err := section.NewKey("cf_api_email", p.client.apiEmail); err != nil {
+		return err
+	}
+	_, err := section.NewKey("cf_api_key", p.client.apiKey)
+	return err
+}
@@ -0,0 +1,194 @@
+// Package digitalocean implements provider.Provider for DigitalOcean DNS.
+package digitalocean
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+
+	"github.com/go-ini/ini"
+
+	"github.com/alexrsagen/go-certbot-cloudflare/internal/provider"
+)
+
+const (
+	name    = "digitalocean"
+	apiBase = "https://api.digitalocean.com/v2/"
+)
+
+// nameservers are DigitalOcean's fixed DNS nameservers, shared by every
+// domain hosted there; DigitalOcean does not expose per-domain delegation.
+var nameservers = []string{"ns1.digitalocean.com", "ns2.digitalocean.com", "ns3.digitalocean.com"}
+
+func init() {
+	provider.Register(name, New)
+}
+
+// DigitalOcean implements provider.Provider against the DigitalOcean DNS
+// API v2.
+type DigitalOcean struct {
+	token string
+}
+
+// New constructs a DigitalOcean provider. The API token is taken from
+// DIGITALOCEAN_TOKEN, falling back to the do_token key under the renewal
+// ini file's [go-certbot-dns-digitalocean] section.
+func New(renewFile *ini.File) (provider.Provider, error) {
+	token := os.Getenv("DIGITALOCEAN_TOKEN")
+	if token == "" && renewFile != nil {
+		token = renewFile.Section(provider.SectionName(name)).Key("do_token").String()
+	}
+	return &DigitalOcean{token: token}, nil
+}
+
+type domainRecord struct {
+	ID   int    `json:"id"`
+	Type string `json:"type"`
+	Name string `json:"name"`
+	Data string `json:"data"`
+}
+
+type listRecordsResponse struct {
+	DomainRecords []domainRecord `json:"domain_records"`
+}
+
+type createRecordRequest struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+	Data string `json:"data"`
+}
+
+func (p *DigitalOcean) do(method, urlExt string, v url.Values, body interface{}) (*http.Response, error) {
+	base, err := url.Parse(apiBase)
+	if err != nil {
+		return nil, err
+	}
+	ext, err := url.Parse(urlExt)
+	if err != nil {
+		return nil, err
+	}
+	var reqBody *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewReader(data)
+	} else {
+		reqBody = bytes.NewReader(nil)
+	}
+	req, err := http.NewRequest(method, base.ResolveReference(ext).String(), reqBody)
+	if err != nil {
+		return nil, err
+	}
+	if v != nil {
+		req.URL.RawQuery = v.Encode()
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.token)
+	return http.DefaultClient.Do(req)
+}
+
+// FindZone implements provider.Provider. DigitalOcean domains map 1:1 to
+// apex zones, so domain must be an exact apex match; the caller walks up
+// the label tree to find it.
+func (p *DigitalOcean) FindZone(domain string) (*provider.Zone, error) {
+	res, err := p.do(http.MethodGet, "domains/"+domain, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to look up zone: unexpected status %s", res.Status)
+	}
+	return &provider.Zone{ID: domain, Name: domain}, nil
+}
+
+// UpsertTXT implements provider.Provider.
+func (p *DigitalOcean) UpsertTXT(zone *provider.Zone, name, value string) error {
+	records, err := p.listTXT(zone, name)
+	if err != nil {
+		return err
+	}
+	for i := range records {
+		if records[i].Data == value {
+			return nil
+		}
+	}
+	res, err := p.do(http.MethodPost, "domains/"+zone.Name+"/records", nil, &createRecordRequest{
+		Type: "TXT",
+		Name: recordNameRelativeToZone(name, zone.Name),
+		Data: value,
+	})
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != http.StatusCreated {
+		return fmt.Errorf("failed to create challenge record: unexpected status %s", res.Status)
+	}
+	return nil
+}
+
+// DeleteTXT implements provider.Provider.
+func (p *DigitalOcean) DeleteTXT(zone *provider.Zone, name, value string) error {
+	records, err := p.listTXT(zone, name)
+	if err != nil {
+		return err
+	}
+	for i := range records {
+		if records[i].Data != value {
+			continue
+		}
+		res, err := p.do(http.MethodDelete, "domains/"+zone.Name+"/records/"+strconv.Itoa(records[i].ID), nil, nil)
+		if err != nil {
+			return err
+		}
+		if res.StatusCode != http.StatusNoContent {
+			return fmt.Errorf("failed to delete challenge record: unexpected status %s", res.Status)
+		}
+	}
+	return nil
+}
+
+func (p *DigitalOcean) listTXT(zone *provider.Zone, name string) ([]domainRecord, error) {
+	res, err := p.do(http.MethodGet, "domains/"+zone.Name+"/records", url.Values{
+		"type":     []string{"TXT"},
+		"name":     []string{name},
+		"per_page": []string{"100"},
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to list TXT records: unexpected status %s", res.Status)
+	}
+	listRes := &listRecordsResponse{}
+	d := json.NewDecoder(res.Body)
+	if err = d.Decode(listRes); err != nil {
+		return nil, err
+	}
+	return listRes.DomainRecords, nil
+}
+
+// AuthoritativeNameservers implements provider.Provider.
+func (p *DigitalOcean) AuthoritativeNameservers(zone *provider.Zone) ([]string, error) {
+	return nameservers, nil
+}
+
+// recordNameRelativeToZone converts a fully-qualified record name such as
+// "_acme-challenge.sub.example.com" into the zone-relative name
+// DigitalOcean's API expects, e.g. "_acme-challenge.sub" for zone
+// "example.com".
+func recordNameRelativeToZone(name, zoneName string) string {
+	suffix := "." + zoneName
+	if len(name) > len(suffix) && name[len(name)-len(suffix):] == suffix {
+		return name[:len(name)-len(suffix)]
+	}
+	return "@"
+}
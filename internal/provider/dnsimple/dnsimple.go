@@ -0,0 +1,204 @@
+// Package dnsimple implements provider.Provider for DNSimple DNS.
+package dnsimple
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-ini/ini"
+
+	"github.com/alexrsagen/go-certbot-cloudflare/internal/provider"
+)
+
+const (
+	name    = "dnsimple"
+	apiBase = "https://api.dnsimple.com/v2/"
+)
+
+func init() {
+	provider.Register(name, New)
+}
+
+// DNSimple implements provider.Provider against the DNSimple API v2.
+type DNSimple struct {
+	token     string
+	accountID string
+}
+
+// New constructs a DNSimple provider. The API token and account ID are
+// taken from DNSIMPLE_TOKEN/DNSIMPLE_ACCOUNT_ID, falling back to the
+// dnsimple_token/dnsimple_account_id keys under the renewal ini file's
+// [go-certbot-dns-dnsimple] section.
+func New(renewFile *ini.File) (provider.Provider, error) {
+	token := os.Getenv("DNSIMPLE_TOKEN")
+	accountID := os.Getenv("DNSIMPLE_ACCOUNT_ID")
+	if renewFile != nil {
+		section := renewFile.Section(provider.SectionName(name))
+		if token == "" {
+			token = section.Key("dnsimple_token").String()
+		}
+		if accountID == "" {
+			accountID = section.Key("dnsimple_account_id").String()
+		}
+	}
+	return &DNSimple{token: token, accountID: accountID}, nil
+}
+
+type zoneRecord struct {
+	ID      int    `json:"id"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+}
+
+type listRecordsResponse struct {
+	Data []zoneRecord `json:"data"`
+}
+
+type createRecordRequest struct {
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	TTL     int    `json:"ttl,omitempty"`
+}
+
+func (p *DNSimple) do(method, urlExt string, v url.Values, body interface{}) (*http.Response, error) {
+	base, err := url.Parse(apiBase)
+	if err != nil {
+		return nil, err
+	}
+	ext, err := url.Parse(urlExt)
+	if err != nil {
+		return nil, err
+	}
+	var reqBody *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewReader(data)
+	} else {
+		reqBody = bytes.NewReader(nil)
+	}
+	req, err := http.NewRequest(method, base.ResolveReference(ext).String(), reqBody)
+	if err != nil {
+		return nil, err
+	}
+	if v != nil {
+		req.URL.RawQuery = v.Encode()
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.token)
+	return http.DefaultClient.Do(req)
+}
+
+// FindZone implements provider.Provider.
+func (p *DNSimple) FindZone(domain string) (*provider.Zone, error) {
+	res, err := p.do(http.MethodGet, p.accountID+"/zones/"+domain, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to look up zone: unexpected status %s", res.Status)
+	}
+	return &provider.Zone{ID: domain, Name: domain}, nil
+}
+
+// UpsertTXT implements provider.Provider.
+func (p *DNSimple) UpsertTXT(zone *provider.Zone, name, value string) error {
+	records, err := p.listTXT(zone, name)
+	if err != nil {
+		return err
+	}
+	for i := range records {
+		if records[i].Content == value {
+			return nil
+		}
+	}
+	res, err := p.do(http.MethodPost, p.accountID+"/zones/"+zone.Name+"/records", nil, &createRecordRequest{
+		Type:    "TXT",
+		Name:    recordNameRelativeToZone(name, zone.Name),
+		Content: value,
+		TTL:     60,
+	})
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != http.StatusCreated {
+		return fmt.Errorf("failed to create challenge record: unexpected status %s", res.Status)
+	}
+	return nil
+}
+
+// DeleteTXT implements provider.Provider.
+func (p *DNSimple) DeleteTXT(zone *provider.Zone, name, value string) error {
+	records, err := p.listTXT(zone, name)
+	if err != nil {
+		return err
+	}
+	for i := range records {
+		if records[i].Content != value {
+			continue
+		}
+		res, err := p.do(http.MethodDelete, p.accountID+"/zones/"+zone.Name+"/records/"+strconv.Itoa(records[i].ID), nil, nil)
+		if err != nil {
+			return err
+		}
+		if res.StatusCode != http.StatusNoContent {
+			return fmt.Errorf("failed to delete challenge record: unexpected status %s", res.Status)
+		}
+	}
+	return nil
+}
+
+func (p *DNSimple) listTXT(zone *provider.Zone, name string) ([]zoneRecord, error) {
+	res, err := p.do(http.MethodGet, p.accountID+"/zones/"+zone.Name+"/records", url.Values{
+		"type":     []string{"TXT"},
+		"name":     []string{recordNameRelativeToZone(name, zone.Name)},
+		"per_page": []string{"100"},
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to list TXT records: unexpected status %s", res.Status)
+	}
+	listRes := &listRecordsResponse{}
+	d := json.NewDecoder(res.Body)
+	if err = d.Decode(listRes); err != nil {
+		return nil, err
+	}
+	return listRes.Data, nil
+}
+
+// AuthoritativeNameservers implements provider.Provider. DNSimple assigns
+// the same four nameservers to every zone.
+func (p *DNSimple) AuthoritativeNameservers(zone *provider.Zone) ([]string, error) {
+	return []string{
+		"ns1.dnsimple.com",
+		"ns2.dnsimple.com",
+		"ns3.dnsimple.com",
+		"ns4.dnsimple.com",
+	}, nil
+}
+
+// recordNameRelativeToZone converts a fully-qualified record name such as
+// "_acme-challenge.sub.example.com" into the zone-relative name DNSimple's
+// API expects, e.g. "_acme-challenge.sub" for zone "example.com".
+func recordNameRelativeToZone(name, zoneName string) string {
+	suffix := "." + zoneName
+	if strings.HasSuffix(name, suffix) {
+		return name[:len(name)-len(suffix)]
+	}
+	return ""
+}
@@ -0,0 +1,94 @@
+// Package provider defines the interface go-certbot-cloudflare uses to
+// manage the _acme-challenge TXT record on a user's DNS host, and the
+// registry each concrete DNS host implementation registers itself with.
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/go-ini/ini"
+)
+
+// Zone identifies a DNS zone on a provider.
+type Zone struct {
+	ID   string
+	Name string
+}
+
+// ErrZoneInvalid is returned, wrapped, by a Provider method when the host
+// rejects a Zone's ID as no longer valid (for example Cloudflare's API
+// error 1001), typically because the zone was deleted or recreated since it
+// was looked up. Callers that persist zone lookups across invocations
+// should treat this as a signal to drop their cached entry and re-resolve
+// the zone from scratch.
+var ErrZoneInvalid = errors.New("zone ID is no longer valid")
+
+// Provider is implemented by each supported DNS host. It abstracts the
+// handful of operations the ACME manual hook needs: resolving the zone for
+// a domain, creating/removing the _acme-challenge TXT record, and finding
+// the zone's authoritative nameservers for propagation checks.
+type Provider interface {
+	// FindZone returns the zone that would hold records for domain, or nil
+	// if no such zone exists with the configured credentials.
+	FindZone(domain string) (*Zone, error)
+	// UpsertTXT ensures a TXT record named name with content value exists
+	// in zone, creating it if it does not already exist.
+	UpsertTXT(zone *Zone, name, value string) error
+	// DeleteTXT removes the TXT record named name with content value from
+	// zone, if present.
+	DeleteTXT(zone *Zone, name, value string) error
+	// AuthoritativeNameservers returns the nameservers that are
+	// authoritative for zone, so propagation can be checked directly
+	// against them.
+	AuthoritativeNameservers(zone *Zone) ([]string, error)
+}
+
+// CredentialSaver is optionally implemented by providers that support
+// persisting the credentials they were configured with into the section
+// passed to --save-renew-creds, so future renewals don't depend on
+// environment variables being set again.
+type CredentialSaver interface {
+	SaveCredentials(section *ini.Section) error
+}
+
+// Factory constructs a Provider, loading its credentials from the
+// environment and/or its section of the certbot renewal ini file.
+// renewFile may be nil if the renewal file could not be loaded; providers
+// must tolerate that and rely on environment variables alone in that case.
+type Factory func(renewFile *ini.File) (Provider, error)
+
+var factories = make(map[string]Factory)
+
+// Register adds a Provider factory under name, so it can be selected via
+// --provider/CERTBOT_PROVIDER. It is meant to be called from each provider
+// package's init function.
+func Register(name string, f Factory) {
+	factories[name] = f
+}
+
+// New constructs the Provider registered under name.
+func New(name string, renewFile *ini.File) (Provider, error) {
+	f, ok := factories[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown DNS provider %q (known providers: %v)", name, Names())
+	}
+	return f(renewFile)
+}
+
+// Names returns the names of all registered providers, sorted.
+func Names() []string {
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SectionName returns the renewal ini section a provider named name should
+// load its credentials from and save them to.
+func SectionName(name string) string {
+	return "go-certbot-dns-" + name
+}
@@ -0,0 +1,242 @@
+// Package route53 implements provider.Provider for Amazon Route 53.
+package route53
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/aws/aws-sdk-go-v2/service/route53/types"
+	"github.com/go-ini/ini"
+
+	"github.com/alexrsagen/go-certbot-cloudflare/internal/provider"
+)
+
+const name = "route53"
+
+func init() {
+	provider.Register(name, New)
+}
+
+// Route53 implements provider.Provider against the Amazon Route 53 API.
+type Route53 struct {
+	client *route53.Client
+
+	mu         sync.Mutex
+	rrsetLocks map[string]*sync.Mutex // "zoneID/name" -> lock guarding that RRset's read-modify-write
+}
+
+// New constructs a Route53 provider. Credentials and region are taken from
+// the standard AWS_ACCESS_KEY_ID/AWS_SECRET_ACCESS_KEY/AWS_SESSION_TOKEN/
+// AWS_REGION environment variables (and, transitively, ~/.aws/credentials),
+// falling back to the matching keys under the renewal ini file's
+// [go-certbot-dns-route53] section.
+func New(renewFile *ini.File) (provider.Provider, error) {
+	accessKeyID := os.Getenv("AWS_ACCESS_KEY_ID")
+	secretAccessKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
+	sessionToken := os.Getenv("AWS_SESSION_TOKEN")
+	region := os.Getenv("AWS_REGION")
+
+	if renewFile != nil {
+		section := renewFile.Section(provider.SectionName(name))
+		if accessKeyID == "" {
+			accessKeyID = section.Key("aws_access_key_id").String()
+		}
+		if secretAccessKey == "" {
+			secretAccessKey = section.Key("aws_secret_access_key").String()
+		}
+		if sessionToken == "" {
+			sessionToken = section.Key("aws_session_token").String()
+		}
+		if region == "" {
+			region = section.Key("aws_region").String()
+		}
+	}
+
+	var optFns []func(*awsconfig.LoadOptions) error
+	if region != "" {
+		optFns = append(optFns, awsconfig.WithRegion(region))
+	}
+	if accessKeyID != "" && secretAccessKey != "" {
+		optFns = append(optFns, awsconfig.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(accessKeyID, secretAccessKey, sessionToken),
+		))
+	}
+
+	cfg, err := awsconfig.LoadDefaultConfig(context.Background(), optFns...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	return &Route53{
+		client:     route53.NewFromConfig(cfg),
+		rrsetLocks: make(map[string]*sync.Mutex),
+	}, nil
+}
+
+// FindZone implements provider.Provider.
+func (p *Route53) FindZone(domain string) (*provider.Zone, error) {
+	dnsName := domain + "."
+	out, err := p.client.ListHostedZonesByName(context.Background(), &route53.ListHostedZonesByNameInput{
+		DNSName:  aws.String(dnsName),
+		MaxItems: aws.Int32(1),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(out.HostedZones) == 0 || aws.ToString(out.HostedZones[0].Name) != dnsName {
+		return nil, nil
+	}
+	z := out.HostedZones[0]
+	return &provider.Zone{ID: strings.TrimPrefix(aws.ToString(z.Id), "/hostedzone/"), Name: domain}, nil
+}
+
+// UpsertTXT implements provider.Provider.
+//
+// Route 53 UPSERT replaces the entire RRset for a name+type, so multiple
+// concurrent TXT values on the same name (e.g. wildcard + apex challenges)
+// must be merged into the existing set rather than submitted as a
+// single-record batch. The read-modify-write is serialized per (zone, name)
+// so that two callers merging concurrently don't race on the same RRset and
+// drop each other's value.
+func (p *Route53) UpsertTXT(zone *provider.Zone, name, value string) error {
+	lock := p.rrsetLock(zone, name)
+	lock.Lock()
+	defer lock.Unlock()
+
+	values, err := p.currentTXT(zone, name)
+	if err != nil {
+		return err
+	}
+	for _, v := range values {
+		if v == value {
+			return nil
+		}
+	}
+	return p.submitTXT(zone, types.ChangeActionUpsert, name, append(values, value))
+}
+
+// DeleteTXT implements provider.Provider.
+//
+// Route 53 DELETE must exactly match the existing RRset's full record list,
+// so the target value is removed from the current set and the remainder is
+// resubmitted, rather than deleting the whole RRset outright. See UpsertTXT
+// for why the read-modify-write is serialized per (zone, name).
+func (p *Route53) DeleteTXT(zone *provider.Zone, name, value string) error {
+	lock := p.rrsetLock(zone, name)
+	lock.Lock()
+	defer lock.Unlock()
+
+	values, err := p.currentTXT(zone, name)
+	if err != nil {
+		return err
+	}
+	remaining := make([]string, 0, len(values))
+	found := false
+	for _, v := range values {
+		if v == value {
+			found = true
+			continue
+		}
+		remaining = append(remaining, v)
+	}
+	if !found {
+		return nil
+	}
+	if len(remaining) == 0 {
+		return p.submitTXT(zone, types.ChangeActionDelete, name, values)
+	}
+	return p.submitTXT(zone, types.ChangeActionUpsert, name, remaining)
+}
+
+// rrsetLock returns the mutex guarding read-modify-write access to the TXT
+// RRset at (zone, name), creating it on first use.
+func (p *Route53) rrsetLock(zone *provider.Zone, name string) *sync.Mutex {
+	key := zone.ID + "/" + name
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	lock, ok := p.rrsetLocks[key]
+	if !ok {
+		lock = &sync.Mutex{}
+		p.rrsetLocks[key] = lock
+	}
+	return lock
+}
+
+// currentTXT returns the unquoted values of the existing TXT RRset at name,
+// or nil if no such RRset exists.
+func (p *Route53) currentTXT(zone *provider.Zone, name string) ([]string, error) {
+	out, err := p.client.ListResourceRecordSets(context.Background(), &route53.ListResourceRecordSetsInput{
+		HostedZoneId:    aws.String(zone.ID),
+		StartRecordName: aws.String(name),
+		StartRecordType: types.RRTypeTxt,
+		MaxItems:        aws.Int32(1),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(out.ResourceRecordSets) == 0 {
+		return nil, nil
+	}
+	rrset := out.ResourceRecordSets[0]
+	if strings.TrimSuffix(aws.ToString(rrset.Name), ".") != name || rrset.Type != types.RRTypeTxt {
+		return nil, nil
+	}
+	values := make([]string, 0, len(rrset.ResourceRecords))
+	for _, rr := range rrset.ResourceRecords {
+		v, err := strconv.Unquote(aws.ToString(rr.Value))
+		if err != nil {
+			v = aws.ToString(rr.Value)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// submitTXT applies action to the TXT RRset at name with the given set of
+// values: an UPSERT replaces the RRset with values, and a DELETE removes it,
+// in which case values must be the RRset's full current record list.
+func (p *Route53) submitTXT(zone *provider.Zone, action types.ChangeAction, name string, values []string) error {
+	records := make([]types.ResourceRecord, len(values))
+	for i, v := range values {
+		// Route 53 stores the literal quoted string for TXT records, unlike
+		// Cloudflare which takes raw content.
+		records[i] = types.ResourceRecord{Value: aws.String(strconv.Quote(v))}
+	}
+	_, err := p.client.ChangeResourceRecordSets(context.Background(), &route53.ChangeResourceRecordSetsInput{
+		HostedZoneId: aws.String(zone.ID),
+		ChangeBatch: &types.ChangeBatch{
+			Changes: []types.Change{{
+				Action: action,
+				ResourceRecordSet: &types.ResourceRecordSet{
+					Name:            aws.String(name),
+					Type:            types.RRTypeTxt,
+					TTL:             aws.Int64(60),
+					ResourceRecords: records,
+				},
+			}},
+		},
+	})
+	return err
+}
+
+// AuthoritativeNameservers implements provider.Provider.
+func (p *Route53) AuthoritativeNameservers(zone *provider.Zone) ([]string, error) {
+	out, err := p.client.GetHostedZone(context.Background(), &route53.GetHostedZoneInput{
+		Id: aws.String(zone.ID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if out.DelegationSet == nil {
+		return nil, nil
+	}
+	return out.DelegationSet.NameServers, nil
+}
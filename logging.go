@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// parseLogLevel parses the --log-level flag value into a slog.Level.
+func parseLogLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("unknown log level %q (one of: debug, info, warn, error)", s)
+	}
+}
+
+// newLogger builds the logger used throughout main, writing to stdout as
+// either human-readable text or newline-delimited JSON. JSON mode is meant
+// for certbot deployments running under systemd/journald or shipping logs
+// to Loki/ELK, where propagation-failure events need to be filterable on
+// stable keys rather than grepped out of a prefixed message string.
+func newLogger(format string, level slog.Level) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case "text":
+		handler = slog.NewTextHandler(os.Stdout, opts)
+	case "json":
+		handler = slog.NewJSONHandler(os.Stdout, opts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q (one of: text, json)", format)
+	}
+	return slog.New(handler), nil
+}
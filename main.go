@@ -2,56 +2,129 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"log/slog"
 	"net"
-	"net/url"
 	"os"
 	"path"
 	"strings"
 	"time"
 
 	"github.com/go-ini/ini"
+
+	"github.com/alexrsagen/go-certbot-cloudflare/internal/provider"
+
+	// Side-effect imports: each registers itself with the provider package.
+	_ "github.com/alexrsagen/go-certbot-cloudflare/internal/provider/cloudflare"
+	_ "github.com/alexrsagen/go-certbot-cloudflare/internal/provider/digitalocean"
+	_ "github.com/alexrsagen/go-certbot-cloudflare/internal/provider/dnsimple"
+	_ "github.com/alexrsagen/go-certbot-cloudflare/internal/provider/route53"
 )
 
 const chRecName = "_acme-challenge"
+const defaultProvider = "cloudflare"
+
+// certbotListSeparator splits CERTBOT_DOMAIN/CERTBOT_VALIDATION into their
+// per-SAN entries. Certbot's --manual hook is normally invoked once per
+// domain on a multi-domain/SAN cert, but some certbot versions instead
+// invoke it once with every domain and validation token joined by commas.
+const certbotListSeparator = ","
+
+// challenge is one _acme-challenge TXT record that needs to be created (or
+// removed) for a single domain on the certificate being issued.
+type challenge struct {
+	domain    string
+	subdomain string
+	value     string
+	zone      *provider.Zone
+}
 
 func main() {
 	// Get command-line flags
 	cleanup := flag.Bool("cleanup", false, "Sets cleanup mode (to be used in --manual-cleanup-hook)")
-	verbose := flag.Bool("verbose", false, "Enables verbose output")
 	renewPath := flag.String("renew-path", "/etc/letsencrypt/renewal/", "Let's Encrypt renew folder path")
-	saveRenewCreds := flag.Bool("save-renew-creds", false, "Save Cloudflare credentials to Let's Encrypt renew config?")
-	onlySaveRenewCreds := flag.Bool("only-save-renew-creds", false, "Do nothing other than save Cloudflare credentials to Let's Encrypt renew config?")
+	saveRenewCreds := flag.Bool("save-renew-creds", false, "Save DNS provider credentials to Let's Encrypt renew config?")
+	onlySaveRenewCreds := flag.Bool("only-save-renew-creds", false, "Do nothing other than save DNS provider credentials to Let's Encrypt renew config?")
+	providerName := flag.String("provider", "", fmt.Sprintf("DNS provider to use (default \"%s\"). One of: %s", defaultProvider, strings.Join(provider.Names(), ", ")))
+	propagationTimeout := flag.Duration("propagation-timeout", 120*time.Second, "Overall deadline to wait for the challenge record to propagate")
+	propagationInterval := flag.Duration("propagation-interval", 2*time.Second, "Initial interval between propagation checks, before exponential backoff")
+	maxParallelRequests := flag.Int("max-parallel-requests", 4, "Maximum number of concurrent DNS provider requests when a certificate covers multiple domains")
+	zoneCachePath := flag.String("zone-cache", defaultZoneCachePath(), "Path to the persistent zone/nameserver cache file")
+	noZoneCache := flag.Bool("no-zone-cache", false, "Disable the persistent zone/nameserver cache")
+	flushZoneCache := flag.Bool("flush-zone-cache", false, "Flush the persistent zone/nameserver cache before running")
+	logLevelFlag := flag.String("log-level", "info", "Log level: debug, info, warn, error")
+	logFormatFlag := flag.String("log-format", "text", "Log output format: text, json")
 	flag.Parse()
 
+	logLevel, err := parseLogLevel(*logLevelFlag)
+	if err != nil {
+		fmt.Println("[error]", err)
+		return
+	}
+	logger, err := newLogger(*logFormatFlag, logLevel)
+	if err != nil {
+		fmt.Println("[error]", err)
+		return
+	}
+
 	if *onlySaveRenewCreds {
 		*saveRenewCreds = true
 	}
 
+	// An empty path makes every zoneCacheFile load and save below a no-op
+	// (entries then live only in memory for this invocation), so
+	// --no-zone-cache only needs to be handled in one place.
+	if *noZoneCache {
+		*zoneCachePath = ""
+	}
+	if *flushZoneCache && *zoneCachePath != "" {
+		if err := os.Remove(*zoneCachePath); err != nil && !os.IsNotExist(err) {
+			logger.Warn("failed to flush zone cache", "path", *zoneCachePath, "err", err)
+		}
+	}
+	diskZoneCache := loadZoneCacheFile(*zoneCachePath)
+
 	// Get environment variables
 	domain, ok := os.LookupEnv("CERTBOT_DOMAIN")
 	if !ok {
-		fmt.Println("[error] Environment variable CERTBOT_DOMAIN not set")
+		logger.Error("environment variable CERTBOT_DOMAIN not set")
 		return
 	}
 	vt, ok := os.LookupEnv("CERTBOT_VALIDATION")
 	if !ok {
-		fmt.Println("[error] Environment variable CERTBOT_VALIDATION not set")
+		logger.Error("environment variable CERTBOT_VALIDATION not set")
 		return
 	}
-	cfAPIEmail, ok := os.LookupEnv("CF_API_EMAIL")
-	if !ok && *verbose {
-		fmt.Println("[warning] Environment variable CF_API_EMAIL not set, now depending on renew config")
+
+	rawDomains := strings.Split(domain, certbotListSeparator)
+	rawValues := strings.Split(vt, certbotListSeparator)
+	if len(rawDomains) != len(rawValues) {
+		logger.Error("CERTBOT_DOMAIN/CERTBOT_VALIDATION entry count mismatch", "domain_count", len(rawDomains), "value_count", len(rawValues))
+		return
 	}
-	cfAPIKey, ok := os.LookupEnv("CF_API_KEY")
-	if !ok && *verbose {
-		fmt.Println("[warning] Environment variable CF_API_KEY not set, now depending on renew config")
+	challenges := make([]*challenge, len(rawDomains))
+	for i := range rawDomains {
+		d := strings.TrimSpace(rawDomains[i])
+		var subdomain string
+		if len(d) > 2 && d[:2] == "*." {
+			subdomain = chRecName + "." + d[2:]
+		} else {
+			subdomain = chRecName + "." + d
+		}
+		challenges[i] = &challenge{domain: d, subdomain: subdomain, value: strings.TrimSpace(rawValues[i])}
 	}
 
-	// Get renewal file path
-	renewDomain := domain
+	if *providerName == "" {
+		*providerName = os.Getenv("CERTBOT_PROVIDER")
+	}
+	if *providerName == "" {
+		*providerName = defaultProvider
+	}
+
+	// Get renewal file path, keyed off the first domain on the cert
+	renewDomain := challenges[0].domain
 	var renewFilePath string
 	for {
 		renewFilePath = path.Join(*renewPath, renewDomain+".conf")
@@ -61,287 +134,231 @@ func main() {
 		tldPos := strings.LastIndexByte(renewDomain, '.')
 		sldPos := strings.IndexByte(renewDomain, '.')
 		if sldPos == tldPos || sldPos == -1 {
-			fmt.Println("[error] Certbot renewal file not found")
+			logger.Error("certbot renewal file not found", "domain", challenges[0].domain)
 			return
 		}
 		renewDomain = renewDomain[sldPos+1:]
 	}
 
-	// Load API email and/or key from renewal file
-	if cfAPIEmail == "" || cfAPIKey == "" {
-		file, err := ini.Load(renewFilePath)
-		if err != nil {
-			fmt.Printf("[error] Failed to load file \"%s\"\n%v\n", renewFilePath, err)
-			return
-		}
-		section := file.Section("go-certbot-cloudflare")
-		if section == nil {
-			fmt.Printf("[error] Could not find section \"go-certbot-cloudflare\" in file \"%s\"\n", renewFilePath)
-			return
-		}
-		if cfAPIEmail == "" {
-			keyAPIEmail := section.Key("cf_api_email")
-			if keyAPIEmail == nil {
-				fmt.Printf("[error] Could not find key \"cf_api_email\" under section \"go-certbot-cloudflare\" in file \"%s\"\n", renewFilePath)
-				return
-			}
-			cfAPIEmail = keyAPIEmail.String()
-		}
-		if cfAPIKey == "" {
-			keyAPIKey := section.Key("cf_api_key")
-			if keyAPIKey == nil {
-				fmt.Printf("[error] Could not find key \"cf_api_key\" under section \"go-certbot-cloudflare\" in file \"%s\"\n", renewFilePath)
-				return
-			}
-			cfAPIKey = keyAPIKey.String()
-		}
+	// Renewal ini file is optional input to provider construction: a
+	// provider configured entirely through the environment works even if
+	// this fails to load, so only the later --save-renew-creds path treats
+	// a load failure as fatal.
+	renewFile, err := ini.Load(renewFilePath)
+	if err != nil {
+		renewFile = nil
+		logger.Debug("could not load renew config, depending on environment variables only", "path", renewFilePath, "err", err)
 	}
-	if cfAPIEmail == "" || cfAPIKey == "" {
-		fmt.Println("[error] Cloudflare email or API key is empty")
+
+	prov, err := provider.New(*providerName, renewFile)
+	if err != nil {
+		logger.Error("failed to initialize DNS provider", "provider", *providerName, "err", err)
 		return
 	}
 
-	// Get zone information from Cloudflare API
-	zonesRes := &cfListZonesResponse{}
-	zoneDomain := domain
-	for {
-		if *verbose {
-			fmt.Printf("[info] Looking up zone %s in Cloudflare account\n", zoneDomain)
-		}
-		httpRes, err := cfGet(cfAPIEmail, cfAPIKey, "zones", url.Values{
-			"name":     []string{zoneDomain},
-			"status":   []string{"active"},
-			"page":     []string{"1"},
-			"per_page": []string{"1"},
-			"match":    []string{"all"},
-		})
+	// Resolve the zone for each domain, caching lookups across entries
+	// that share a zone (e.g. an apex and a wildcard on the same cert).
+	zoneCache := make(map[string]*provider.Zone)
+	for _, c := range challenges {
+		logger.Debug("looking up zone", "domain", c.domain, "provider", *providerName)
+		zone, err := findZoneCached(logger, prov, zoneCache, diskZoneCache, *zoneCachePath, *providerName, c.domain)
 		if err != nil {
-			fmt.Printf("[error] Cloudflare request failed\n%v\n", err)
-			return
-		}
-		d := json.NewDecoder(httpRes.Body)
-		if err = d.Decode(zonesRes); err != nil {
-			fmt.Printf("[error] Failed to decode Cloudflare response\n%v\n", err)
+			logger.Error("zone lookup failed", "domain", c.domain, "err", err)
 			return
 		}
-		if !zonesRes.Success {
-			fmt.Println("[error] Failed to look up zone")
-			for i := range zonesRes.Errors {
-				fmt.Println(zonesRes.Errors[i])
-			}
+		if zone == nil {
+			logger.Error("zone not found with DNS provider", "domain", c.domain)
 			return
 		}
-		if len(zonesRes.Result) == 0 {
-			if *verbose {
-				fmt.Printf("[info] Zone \"%s\" not found in Cloudflare account, trying one subdomain less\n", zoneDomain)
-			}
-			tldPos := strings.LastIndexByte(zoneDomain, '.')
-			sldPos := strings.IndexByte(zoneDomain, '.')
-			if sldPos == tldPos || sldPos == -1 {
-				fmt.Println("[error] Zone not found in Cloudflare account")
-				return
-			}
-			zoneDomain = zoneDomain[sldPos+1:]
-			zonesRes = &cfListZonesResponse{}
-			continue
-		}
-		break
-	}
-	if len(zonesRes.Result[0].Nameservers) < 2 {
-		fmt.Println("[error] Could not find two or more nameservers in zone")
-		return
+		c.zone = zone
 	}
 
-	var subdomain string
-	if len(domain) > 2 && domain[:2] == "*." {
-		subdomain = chRecName + "." + domain[2:]
-	} else {
-		subdomain = chRecName + "." + domain
+	// Group challenges by zone, so each zone's records can be created or
+	// removed together and its propagation checked as one batch.
+	zoneGroups := make(map[string][]*challenge)
+	var zoneOrder []string
+	for _, c := range challenges {
+		if _, ok := zoneGroups[c.zone.ID]; !ok {
+			zoneOrder = append(zoneOrder, c.zone.ID)
+		}
+		zoneGroups[c.zone.ID] = append(zoneGroups[c.zone.ID], c)
 	}
 
 	if *cleanup { // Cleanup mode
-		// Get _acme-challenge TXT records from Cloudflare API
-		if *verbose {
-			fmt.Println("[info] Looking up DNS ACME challenge records in Cloudflare zone")
-		}
-		httpRes, err := cfGet(cfAPIEmail, cfAPIKey, "zones/"+zonesRes.Result[0].ID+"/dns_records", url.Values{
-			"type":     []string{"TXT"},
-			"name":     []string{subdomain},
-			"page":     []string{"1"},
-			"per_page": []string{"100"},
-			"match":    []string{"all"},
-		})
-		recordsRes := &cfListRecordsResponse{}
-		d := json.NewDecoder(httpRes.Body)
-		if err = d.Decode(recordsRes); err != nil {
-			fmt.Printf("[error] Failed to decode Cloudflare response\n%v\n", err)
-			return
-		}
-		if len(recordsRes.Result) == 0 {
-			if *verbose {
-				fmt.Println("[info] No challenge records to clean up")
+		for _, zoneID := range zoneOrder {
+			group := zoneGroups[zoneID]
+			err := parallelize(len(group), *maxParallelRequests, func(i int) error {
+				c := group[i]
+				logger.Debug("deleting challenge record", "domain", c.subdomain, "value", c.value)
+				return prov.DeleteTXT(c.zone, c.subdomain, c.value)
+			})
+			if err != nil {
+				if errors.Is(err, provider.ErrZoneInvalid) {
+					diskZoneCache.deleteAndSave(*zoneCachePath, zoneCacheKey(*providerName, group[0].zone.Name))
+				}
+				logger.Error("failed to delete challenge record", "zone_id", zoneID, "err", err)
+				return
 			}
-			return
 		}
+	} else if !*onlySaveRenewCreds { // Auth/normal mode
+		for _, zoneID := range zoneOrder {
+			group := zoneGroups[zoneID]
+			zone := group[0].zone
+			nsCacheKey := zoneCacheKey(*providerName, zone.Name)
 
-		// Delete all _acme-challenge TXT records with Cloudflare API
-		if *verbose {
-			fmt.Printf("[info] Found %d challenge record(s) to clean up\n", len(recordsRes.Result))
-		}
-		for i := range recordsRes.Result {
-			if *verbose {
-				fmt.Printf("[info] Deleting challenge record TXT %s: \"%s\"\n", recordsRes.Result[i].Name, recordsRes.Result[i].Content)
+			var nameservers []string
+			if entry, ok := diskZoneCache.get(nsCacheKey, defaultZoneCacheTTL); ok && len(entry.Nameservers) > 0 {
+				nameservers = entry.Nameservers
+			} else {
+				var err error
+				nameservers, err = prov.AuthoritativeNameservers(zone)
+				if err != nil {
+					if errors.Is(err, provider.ErrZoneInvalid) {
+						diskZoneCache.deleteAndSave(*zoneCachePath, nsCacheKey)
+					}
+					logger.Error("failed to look up authoritative nameservers", "zone_id", zone.ID, "err", err)
+					return
+				}
+				if err := diskZoneCache.setAndSave(*zoneCachePath, nsCacheKey, zoneCacheEntry{
+					ZoneID:      zone.ID,
+					ZoneName:    zone.Name,
+					Nameservers: nameservers,
+					FetchedAt:   time.Now(),
+				}); err != nil {
+					logger.Warn("failed to write zone cache", "path", *zoneCachePath, "err", err)
+				}
 			}
-			httpRes, err := cfDelete(cfAPIEmail, cfAPIKey, "zones/"+zonesRes.Result[0].ID+"/dns_records/"+recordsRes.Result[i].ID, nil)
-			if err != nil {
-				fmt.Printf("[error] Cloudflare request failed\n%v\n", err)
+			if len(nameservers) < 2 {
+				logger.Error("zone has fewer than two authoritative nameservers", "zone_id", zone.ID)
 				return
 			}
-			deleteRes := &cfDeleteRecordResponse{}
-			d := json.NewDecoder(httpRes.Body)
-			if err = d.Decode(deleteRes); err != nil {
-				fmt.Printf("[error] Failed to decode Cloudflare response\n%v\n", err)
+
+			// Resolve IP of first nameserver
+			addr1, err := net.ResolveUDPAddr("udp", nameservers[0]+":53")
+			if err != nil {
+				logger.Error("could not resolve nameserver", "nameserver", nameservers[0], "err", err)
 				return
 			}
-			if !deleteRes.Success {
-				fmt.Println("[error] Failed to delete challenge record")
-				for i := range deleteRes.Errors {
-					fmt.Println(deleteRes.Errors[i])
-				}
-				return
+			rs1 := &net.Resolver{
+				PreferGo: true,
+				Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+					d := net.Dialer{}
+					return d.DialContext(ctx, "udp", addr1.String())
+				},
 			}
-		}
-	} else if !*onlySaveRenewCreds { // Auth/normal mode
-		// Resolve IP of first nameserver
-		addr1, err := net.ResolveUDPAddr("udp", zonesRes.Result[0].Nameservers[0]+":53")
-		if err != nil {
-			fmt.Printf("[error] Could not resolve nameserver in CF_NS1\n%v\n", err)
-			return
-		}
-		rs1 := net.Resolver{
-			PreferGo: true,
-			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
-				d := net.Dialer{}
-				return d.DialContext(ctx, "udp", addr1.String())
-			},
-		}
 
-		// Resolve IP of second nameserver
-		addr2, err := net.ResolveUDPAddr("udp", zonesRes.Result[0].Nameservers[1]+":53")
-		if err != nil {
-			fmt.Printf("[error] Could not resolve nameserver in CF_NS2\n%v\n", err)
-			return
-		}
-		rs2 := net.Resolver{
-			PreferGo: true,
-			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
-				d := net.Dialer{}
-				return d.DialContext(ctx, "udp", addr2.String())
-			},
-		}
-
-		// Perform initial lookup of _acme-challenge TXT records using the Cloudflare DNS servers
-		if *verbose {
-			fmt.Printf("[info] Attempting initial lookup TXT %s\n", subdomain)
-		}
-		dnsRes, err := lookupCompareTXT(rs1, rs2, subdomain)
-		if err == nil && strSliceLookup(dnsRes, vt) {
-			if *verbose {
-				fmt.Println("[info] Expected challenge record already exists on domain")
+			// Resolve IP of second nameserver
+			addr2, err := net.ResolveUDPAddr("udp", nameservers[1]+":53")
+			if err != nil {
+				logger.Error("could not resolve nameserver", "nameserver", nameservers[1], "err", err)
+				return
 			}
-			return
-		}
-
-		// If initial lookup could not find records,
-		// create _acme-challenge TXT records using the Cloudflare API.
-		if *verbose {
-			fmt.Println("[info] Challenge record not found on domain")
-			fmt.Printf("[info] Creating TXT record %s with content \"%s\"\n", subdomain, vt)
-		}
-		httpRes, err := cfPostJSON(cfAPIEmail, cfAPIKey, "zones/"+zonesRes.Result[0].ID+"/dns_records", &cfCreateDNSRecord{
-			Type:    "TXT",
-			Name:    subdomain,
-			Content: vt,
-		})
-		if err != nil {
-			fmt.Printf("[error] Cloudflare request failed\n%v\n", err)
-			return
-		}
-		createRes := &cfCreateRecordResponse{}
-		d := json.NewDecoder(httpRes.Body)
-		if err = d.Decode(createRes); err != nil {
-			fmt.Printf("[error] Failed to decode Cloudflare response\n%v\n", err)
-			return
-		}
-		if !createRes.Success {
-			fmt.Println("[error] Failed to create challenge record")
-			for i := range createRes.Errors {
-				fmt.Println(createRes.Errors[i])
+			rs2 := &net.Resolver{
+				PreferGo: true,
+				Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+					d := net.Dialer{}
+					return d.DialContext(ctx, "udp", addr2.String())
+				},
 			}
-			return
-		}
 
-		// Wait for new _acme-challenge TXT record to update on Cloudflare nameservers
-		if *verbose {
-			fmt.Printf("[info] Attempting lookup TXT %s\n", subdomain)
-		}
-		dnsRes = nil
-		attempts := 0
-		for {
-			attempts++
-			if attempts > 30 {
-				fmt.Println("[error] Did not find expected challenge record, gave up after 30 attempts")
-				return
+			checks := make([]Check, len(group))
+			for i, c := range group {
+				checks[i] = Check{Name: c.subdomain, Value: c.value}
 			}
-			dnsRes, err = lookupCompareTXT(rs1, rs2, subdomain)
-			if err == errInconsistent {
-				if *verbose {
-					fmt.Println(err.Error())
+
+			logger.Debug("waiting for challenge record(s) to propagate", "domain", zone.Name, "zone_id", zone.ID, "count", len(group), "timeout", propagationTimeout.String())
+			err = Propagation(logger, rs1, rs2, nameservers[0], nameservers[1], zone.ID, zone.Name, checks, PropagationConfig{
+				Timeout:  *propagationTimeout,
+				Interval: *propagationInterval,
+			}, func() error {
+				return parallelize(len(group), *maxParallelRequests, func(i int) error {
+					c := group[i]
+					logger.Debug("creating TXT record", "domain", c.subdomain, "value", c.value)
+					return prov.UpsertTXT(c.zone, c.subdomain, c.value)
+				})
+			})
+			if err != nil {
+				if errors.Is(err, provider.ErrZoneInvalid) {
+					diskZoneCache.deleteAndSave(*zoneCachePath, nsCacheKey)
 				}
-				time.Sleep(1 * time.Second)
-				continue
-			} else if err != nil && !strings.Contains(err.Error(), "no such host") {
-				fmt.Printf("[error] Failed lookup TXT %s\n%v\n", subdomain, err)
+				logger.Error("challenge record propagation failed", "zone_id", zone.ID, "err", err)
 				return
 			}
-			if dnsRes == nil || len(dnsRes) == 0 || !strSliceLookup(dnsRes, vt) {
-				if *verbose {
-					fmt.Printf("[info] Challenge record \"%s\" missing from domain, retrying...\n", vt)
-				}
-				time.Sleep(1 * time.Second)
-				continue
-			}
-			break
-		}
-		if *verbose {
-			fmt.Printf("[info] Found expected challenge record after %d attempt(s)\n", attempts)
 		}
 	}
 
-	// Save Cloudflare credentials to Let's Encrypt renew config
+	// Save DNS provider credentials to Let's Encrypt renew config
 	if *saveRenewCreds {
-		file, err := ini.Load(renewFilePath)
-		if err != nil {
-			fmt.Printf("[error] Failed to load file \"%s\"\n%v\n", renewFilePath, err)
+		saver, ok := prov.(provider.CredentialSaver)
+		if !ok {
+			logger.Error("provider does not support --save-renew-creds", "provider", *providerName)
 			return
 		}
-		file.DeleteSection("go-certbot-cloudflare")
-		section, err := file.NewSection("go-certbot-cloudflare")
+		file, err := ini.Load(renewFilePath)
 		if err != nil {
-			fmt.Println("[error] Failed to create section \"go-certbot-cloudflare\"")
+			logger.Error("failed to load renewal file", "path", renewFilePath, "err", err)
 			return
 		}
-		if _, err = section.NewKey("cf_api_email", cfAPIEmail); err != nil {
-			fmt.Println("[error] Failed to create key \"cf_api_email\" in section \"go-certbot-cloudflare\"")
+		sectionName := provider.SectionName(*providerName)
+		file.DeleteSection(sectionName)
+		section, err := file.NewSection(sectionName)
+		if err != nil {
+			logger.Error("failed to create renewal file section", "section", sectionName, "err", err)
 			return
 		}
-		if _, err = section.NewKey("cf_api_key", cfAPIKey); err != nil {
-			fmt.Println("[error] Failed to create key \"cf_api_key\" in section \"go-certbot-cloudflare\"")
+		if err := saver.SaveCredentials(section); err != nil {
+			logger.Error("failed to save credentials to renewal file section", "section", sectionName, "err", err)
 			return
 		}
 		if err = file.SaveTo(renewFilePath); err != nil {
-			fmt.Printf("[error] Failed to save file \"%s\"\n", renewFilePath)
+			logger.Error("failed to save renewal file", "path", renewFilePath, "err", err)
 			return
 		}
 	}
 }
+
+// findZoneCached resolves the zone for domain, walking up its label tree
+// like a single-domain lookup would, but short-circuits through cache
+// whenever an earlier entry already resolved an ancestor (or the same)
+// domain to a zone. It also consults and populates diskCache, so that a
+// zone resolved by a previous hook invocation doesn't cost another FindZone
+// round-trip until defaultZoneCacheTTL elapses.
+func findZoneCached(logger *slog.Logger, prov provider.Provider, cache map[string]*provider.Zone, diskCache *zoneCacheFile, diskCachePath, providerName, domain string) (*provider.Zone, error) {
+	zoneDomain := domain
+	for {
+		if zone, ok := cache[zoneDomain]; ok {
+			cache[domain] = zone
+			return zone, nil
+		}
+		if entry, ok := diskCache.get(zoneCacheKey(providerName, zoneDomain), defaultZoneCacheTTL); ok {
+			zone := &provider.Zone{ID: entry.ZoneID, Name: entry.ZoneName}
+			cache[domain] = zone
+			cache[zoneDomain] = zone
+			cache[zone.Name] = zone
+			return zone, nil
+		}
+		zone, err := prov.FindZone(zoneDomain)
+		if err != nil {
+			return nil, err
+		}
+		if zone != nil {
+			cache[domain] = zone
+			cache[zoneDomain] = zone
+			cache[zone.Name] = zone
+			if err := diskCache.setAndSave(diskCachePath, zoneCacheKey(providerName, zone.Name), zoneCacheEntry{
+				ZoneID:    zone.ID,
+				ZoneName:  zone.Name,
+				FetchedAt: time.Now(),
+			}); err != nil {
+				logger.Warn("failed to write zone cache", "path", diskCachePath, "err", err)
+			}
+			return zone, nil
+		}
+		tldPos := strings.LastIndexByte(zoneDomain, '.')
+		sldPos := strings.IndexByte(zoneDomain, '.')
+		if sldPos == tldPos || sldPos == -1 {
+			return nil, nil
+		}
+		zoneDomain = zoneDomain[sldPos+1:]
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// defaultZoneCacheTTL bounds how long a cached zone lookup (including its
+// nameservers) is trusted before it is re-resolved from the provider.
+const defaultZoneCacheTTL = 24 * time.Hour
+
+// zoneCacheEntry is one cached zone lookup, persisted across hook
+// invocations so repeated certbot renewals for the same account don't each
+// re-resolve the zone and its nameservers from scratch.
+type zoneCacheEntry struct {
+	ZoneID      string    `json:"zone_id"`
+	ZoneName    string    `json:"zone_name"`
+	Nameservers []string  `json:"nameservers,omitempty"`
+	FetchedAt   time.Time `json:"fetched_at"`
+}
+
+// zoneCacheFile is the on-disk format of the zone cache, keyed by
+// zoneCacheKey(providerName, apexDomain).
+type zoneCacheFile struct {
+	Entries map[string]zoneCacheEntry `json:"entries"`
+}
+
+// defaultZoneCachePath returns the default zone cache location,
+// ~/.cache/go-certbot-cloudflare/zones.json, or "" if the user's home
+// directory cannot be determined.
+func defaultZoneCachePath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".cache", "go-certbot-cloudflare", "zones.json")
+}
+
+// zoneCacheKey identifies a cache entry; it is scoped by provider name
+// since the same apex domain could in principle be resolved through
+// different providers across separate cache files sharing one path.
+func zoneCacheKey(providerName, apex string) string {
+	return providerName + "/" + apex
+}
+
+// loadZoneCacheFile reads the zone cache at path, returning an empty cache
+// if it does not exist or cannot be parsed.
+func loadZoneCacheFile(path string) *zoneCacheFile {
+	f := &zoneCacheFile{Entries: make(map[string]zoneCacheEntry)}
+	if path == "" {
+		return f
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return f
+	}
+	if err := json.Unmarshal(data, f); err != nil || f.Entries == nil {
+		return &zoneCacheFile{Entries: make(map[string]zoneCacheEntry)}
+	}
+	return f
+}
+
+// save writes f to path, creating its parent directory if needed.
+func (f *zoneCacheFile) save(path string) error {
+	if path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// get returns the cache entry for key if present and younger than ttl.
+func (f *zoneCacheFile) get(key string, ttl time.Duration) (zoneCacheEntry, bool) {
+	entry, ok := f.Entries[key]
+	if !ok || time.Since(entry.FetchedAt) > ttl {
+		return zoneCacheEntry{}, false
+	}
+	return entry, true
+}
+
+// setAndSave records entry under key and immediately persists the cache to
+// path. Cache writes are infrequent (at most once per resolved zone or
+// nameserver set per hook invocation), so saving eagerly is simpler than
+// tracking a dirty flag across main's several early-return error paths.
+func (f *zoneCacheFile) setAndSave(path, key string, entry zoneCacheEntry) error {
+	f.Entries[key] = entry
+	return f.save(path)
+}
+
+// deleteAndSave drops key from the cache and immediately persists it,
+// forcing the next invocation to re-resolve the zone from the provider.
+func (f *zoneCacheFile) deleteAndSave(path, key string) error {
+	delete(f.Entries, key)
+	return f.save(path)
+}